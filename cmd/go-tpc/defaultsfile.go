@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// socket is the path to a Unix socket to connect through, read from
+// --socket or a my.cnf [client] section. When set it takes precedence
+// over --host/--port in the MySQL DSN, matching mysql(1)'s own rule.
+var socket string
+
+// applyDefaultsFile parses a my.cnf-style file and merges its [client]
+// section into the package-level flag variables it overlaps with,
+// without overwriting anything the user set explicitly on the command
+// line. This lets users keep credentials out of `ps` and shell history,
+// the same way MySQL client tools already do.
+func applyDefaultsFile(path string, explicit map[string]bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open defaults file, err %v", err)
+	}
+	defer f.Close()
+
+	section := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSuffix(strings.TrimPrefix(line, "["), "]")
+			continue
+		}
+		if section != "client" {
+			continue
+		}
+		key, value := splitKV(line)
+		switch key {
+		case "user":
+			setIfNotExplicit(&user, value, explicit, "user")
+		case "password":
+			setIfNotExplicit(&password, value, explicit, "password")
+		case "host":
+			setIfNotExplicit(&host, value, explicit, "host")
+		case "port":
+			if p, err := strconv.Atoi(value); err == nil {
+				setIntIfNotExplicit(&port, p, explicit, "port")
+			}
+		case "socket":
+			setIfNotExplicit(&socket, value, explicit, "socket")
+		}
+		// ssl-ca, ssl-cert and ssl-key are accepted by mysql(1) but go-tpc
+		// has no TLS flags of its own yet, so they're parsed and ignored.
+	}
+	return scanner.Err()
+}
+
+func splitKV(line string) (string, string) {
+	idx := strings.IndexAny(line, "=")
+	if idx < 0 {
+		return strings.TrimSpace(line), ""
+	}
+	key := strings.TrimSpace(line[:idx])
+	value := strings.TrimSpace(line[idx+1:])
+	value = strings.Trim(value, `"'`)
+	return key, value
+}
+
+func setIfNotExplicit(dst *string, value string, explicit map[string]bool, flag string) {
+	if explicit[flag] {
+		return
+	}
+	*dst = value
+}
+
+func setIntIfNotExplicit(dst *int, value int, explicit map[string]bool, flag string) {
+	if explicit[flag] {
+		return
+	}
+	*dst = value
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// failoverGroup holds the ordered list of "host:port" endpoints parsed from
+// --host, forming a failover group similar to Oracle's TWO_TASK. A single
+// host with no separator is a group of one, so existing invocations keep
+// working unchanged.
+var failoverGroup []endpoint
+
+type endpoint struct {
+	host string
+	port int
+}
+
+// failoverEvents counts how many times withFailover moved on to a
+// different endpoint in the group, surfaced through --metrics-addr.
+var failoverEvents uint64
+
+// failoverIdx is the index of the endpoint globalDB currently targets.
+var failoverIdx int32
+
+var failoverMu sync.Mutex
+
+// parseFailoverGroup splits host on "," or "||" into the ordered endpoint
+// list used for failover. Each entry may carry its own ":port", falling
+// back to the global --port for entries that don't.
+func parseFailoverGroup(hostFlag string, defaultPort int) []endpoint {
+	sep := ","
+	if strings.Contains(hostFlag, "||") {
+		sep = "||"
+	}
+	parts := strings.Split(hostFlag, sep)
+	group := make([]endpoint, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		h, ep, err := splitHostPort(p)
+		if err != nil {
+			group = append(group, endpoint{host: p, port: defaultPort})
+			continue
+		}
+		group = append(group, endpoint{host: h, port: ep})
+	}
+	return group
+}
+
+func splitHostPort(hostport string) (string, int, error) {
+	idx := strings.LastIndex(hostport, ":")
+	if idx < 0 {
+		return "", 0, fmt.Errorf("no port in address")
+	}
+	h := hostport[:idx]
+	var p int
+	if _, err := fmt.Sscanf(hostport[idx+1:], "%d", &p); err != nil {
+		return "", 0, err
+	}
+	return h, p, nil
+}
+
+// currentEndpoint returns the endpoint globalDB should target.
+func currentEndpoint() endpoint {
+	if len(failoverGroup) == 0 {
+		return endpoint{host: host, port: port}
+	}
+	return failoverGroup[int(atomic.LoadInt32(&failoverIdx))%len(failoverGroup)]
+}
+
+// advanceEndpoint moves to the next endpoint in the failover group and
+// records the event for --metrics-addr.
+func advanceEndpoint() endpoint {
+	if len(failoverGroup) == 0 {
+		return endpoint{host: host, port: port}
+	}
+	atomic.AddUint64(&failoverEvents, 1)
+	next := atomic.AddInt32(&failoverIdx, 1)
+	return failoverGroup[int(next)%len(failoverGroup)]
+}
+
+// isTransientError reports whether err looks like a failover-worthy
+// condition: the connection was lost, or the server we're talking to just
+// became (or always was) read-only, e.g. because a primary failed over to
+// a replica mid-benchmark.
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == sqldriver.ErrBadConn || err == sql.ErrConnDone {
+		return true
+	}
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "Error 1290"): // MySQL: read-only mode
+		return true
+	case strings.Contains(msg, "Error 2006"): // MySQL server has gone away
+		return true
+	case strings.Contains(msg, "Error 2013"): // MySQL lost connection during query
+		return true
+	case strings.Contains(msg, "read-only transaction"): // Postgres/Cockroach style
+		return true
+	case strings.Contains(msg, "connection refused"):
+		return true
+	case strings.Contains(msg, "broken pipe"):
+		return true
+	}
+	return false
+}
+
+// withFailover runs fn against globalDB, and on a transient error reopens
+// globalDB against the next endpoint in the failover group and retries fn,
+// up to --failover-retries times.
+func withFailover(fn func(*sql.DB) error) error {
+	failoverMu.Lock()
+	db := globalDB
+	failoverMu.Unlock()
+
+	err := fn(db)
+	if err == nil || !isTransientError(err) || len(failoverGroup) <= 1 {
+		return err
+	}
+
+	for attempt := 0; attempt < failoverRetries; attempt++ {
+		ep := advanceEndpoint()
+		fmt.Printf("failover: reconnecting to %s:%d after error: %v\n", ep.host, ep.port, err)
+		sleepBackoff(attempt)
+
+		// reconnect (via dial) reads the new endpoint from currentEndpoint
+		// and writes globalDB itself, each under failoverMu, so it isn't
+		// held across the call here.
+		reconnectErr := reconnect()
+
+		failoverMu.Lock()
+		db = globalDB
+		failoverMu.Unlock()
+
+		if reconnectErr != nil || db == nil {
+			continue
+		}
+		if err = fn(db); err == nil || !isTransientError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func sleepBackoff(attempt int) {
+	d := failoverBackoff * time.Duration(attempt+1)
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
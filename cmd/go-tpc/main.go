@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
@@ -14,31 +13,59 @@ import (
 
 	// mysql package
 	_ "github.com/go-sql-driver/mysql"
+	// postgres package
+	_ "github.com/lib/pq"
+	// sqlite package
+	_ "github.com/mattn/go-sqlite3"
 )
 
+// sqlDriverNames maps the --driver flag value to the name the database/sql
+// driver registered itself under, which doesn't always match.
+var sqlDriverNames = map[string]string{
+	"":          mysqlDriver,
+	mysqlDriver: mysqlDriver,
+	"postgres":  "postgres",
+	"sqlite":    "sqlite3",
+}
+
 var (
-	dbName         string
-	host           string
-	port           int
-	user           string
-	password       string
-	threads        int
-	acThreads      int
-	driver         string
-	totalTime      time.Duration
-	totalCount     int
-	dropData       bool
-	ignoreError    bool
-	outputInterval time.Duration
-	isolationLevel int
-	silence        bool
-	pprofAddr      string
-	metricsAddr    string
-	maxProcs       int
-	connParams     string
+	dbName          string
+	host            string
+	port            int
+	user            string
+	password        string
+	threads         int
+	acThreads       int
+	driver          string
+	totalTime       time.Duration
+	totalCount      int
+	dropData        bool
+	ignoreError     bool
+	outputInterval  time.Duration
+	isolationLevel  int
+	silence         bool
+	pprofAddr       string
+	metricsAddr     string
+	maxProcs        int
+	connParams      string
+	failoverRetries int
+	failoverBackoff time.Duration
+	maxOpenConns    int
+	maxIdleConns    int
+	connMaxLifetime time.Duration
+	connMaxIdleTime time.Duration
+	defaultsFile    string
+	stmtTimeout     time.Duration
+	txnTimeout      time.Duration
 
 	globalDB  *sql.DB
 	globalCtx context.Context
+
+	// poolStatsCancel stops the reportPoolStats goroutine for the
+	// previous globalDB, so a reconnect (e.g. failover) doesn't pile up
+	// one stray goroutine per attempt polling Stats() on an abandoned
+	// pool.
+	poolStatsCancel context.CancelFunc
 )
 
 const (
@@ -54,39 +81,158 @@ func closeDB() {
 	globalDB = nil
 }
 
+// dsnParams returns --conn-params, adding mysql's multiStatements=true
+// (needed for q15 on the TPC-H) when targeting MySQL. Shared by dial and
+// openDB's create-database fallback so they can't drift apart.
+func dsnParams() string {
+	params := connParams
+	if driver == "" || driver == mysqlDriver {
+		if len(params) > 0 {
+			return fmt.Sprintf("multiStatements=true&%s", params)
+		}
+		return "multiStatements=true"
+	}
+	return params
+}
+
+// dial opens globalDB against the current failover endpoint, without pinging or
+// creating the database. Used by both openDB's first connect and
+// reconnect's failover retry so they can't drift apart.
+func dial() error {
+	sqlDriverName, adapter, err := resolveDriver(driver)
+	if err != nil {
+		return err
+	}
+	if failoverGroup == nil {
+		failoverGroup = parseFailoverGroup(host, port)
+	}
+
+	// --host may hold a whole failover group; the endpoint actually being
+	// dialed (including after advanceEndpoint moves on) is tracked by
+	// failoverIdx and read back via currentEndpoint, then copied into
+	// host/port so openDB's create-database fallback targets the same
+	// endpoint. Both happen under the same lock withFailover uses to
+	// read/write globalDB.
+	failoverMu.Lock()
+	ep := currentEndpoint()
+	host, port = ep.host, ep.port
+	failoverMu.Unlock()
+
+	fullDsn := adapter.BuildDSN(user, password, host, port, dbName, dsnParams())
+	db, err := sql.Open(sqlDriverName, fullDsn)
+	if err != nil {
+		return err
+	}
+
+	failoverMu.Lock()
+	globalDB = db
+	failoverMu.Unlock()
+	return nil
+}
+
+// reconnect reopens globalDB against the current failover endpoint. It is
+// used exclusively by withFailover's retry loop: unlike openDB, it never
+// attempts to create a missing database (the group's other endpoints are
+// assumed to already have it) and never calls back into withFailover, so a
+// failover retry can't recurse into itself.
+func reconnect() error {
+	closeDB()
+	if err := dial(); err != nil {
+		return err
+	}
+	applyPoolConfig(globalDB)
+	return nil
+}
+
 func openDB() {
-	// TODO: support other drivers
-	var (
-		tmpDB *sql.DB
-		err   error
-		ds    = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", user, password, host, port, dbName)
-	)
-	// allow multiple statements in one query to allow q15 on the TPC-H
-	fullDsn := fmt.Sprintf("%s?multiStatements=true", ds)
-	if len(connParams) > 0 {
-		fullDsn = fmt.Sprintf("%s&%s", fullDsn, connParams)
-	}
-	globalDB, err = sql.Open(mysqlDriver, fullDsn)
+	closeDB()
+	if err := dial(); err != nil {
+		panic(err)
+	}
+	_, adapter, err := resolveDriver(driver)
 	if err != nil {
 		panic(err)
 	}
-	if err := globalDB.Ping(); err != nil {
-		errString := err.Error()
-		if strings.Contains(errString, unknownDB) {
-			tmpDs := fmt.Sprintf("%s:%s@tcp(%s:%d)/", user, password, host, port)
-			tmpDB, _ = sql.Open(mysqlDriver, tmpDs)
+
+	if pingErr := withFailover(func(db *sql.DB) error { return PingContext(globalCtx, db) }); pingErr != nil {
+		if adapter.IsUnknownDBError(pingErr) {
+			sqlDriverName, _, _ := resolveDriver(driver)
+			tmpDsn := adapter.BuildDSN(user, password, host, port, "", dsnParams())
+			tmpDB, _ := sql.Open(sqlDriverName, tmpDsn)
 			defer tmpDB.Close()
-			if _, err := tmpDB.Exec(createDBDDL + dbName); err != nil {
-				panic(fmt.Errorf("failed to create database, err %v\n", err))
+			if stmt := adapter.CreateDatabaseStmt(dbName); stmt != "" {
+				if _, err := ExecContext(globalCtx, tmpDB, stmt); err != nil {
+					panic(fmt.Errorf("failed to create database, err %v\n", err))
+				}
 			}
+			applyPoolConfig(globalDB)
 		} else {
 			globalDB = nil
 		}
 	} else {
-		globalDB.SetMaxIdleConns(threads + acThreads + 1)
+		applyPoolConfig(globalDB)
 	}
 }
 
+// applyPoolConfig wires --max-open-conns, --max-idle-conns,
+// --conn-max-lifetime and --conn-max-idle-time into db, defaulting the
+// pool size to the workload's thread count so it doesn't grow unbounded
+// under high --threads, and prints the effective config once so it shows
+// up alongside the benchmark's own startup banner.
+func applyPoolConfig(db *sql.DB) {
+	openConns := maxOpenConns
+	if openConns <= 0 {
+		openConns = threads + acThreads
+	}
+	idleConns := maxIdleConns
+	if idleConns <= 0 {
+		idleConns = threads + acThreads + 1
+	}
+	db.SetMaxOpenConns(openConns)
+	db.SetMaxIdleConns(idleConns)
+	db.SetConnMaxLifetime(connMaxLifetime)
+	db.SetConnMaxIdleTime(connMaxIdleTime)
+	fmt.Printf("pool config: max-open-conns=%d max-idle-conns=%d conn-max-lifetime=%s conn-max-idle-time=%s\n",
+		openConns, idleConns, connMaxLifetime, connMaxIdleTime)
+
+	if poolStatsCancel != nil {
+		poolStatsCancel()
+	}
+	var ctx context.Context
+	ctx, poolStatsCancel = context.WithCancel(globalCtx)
+	go reportPoolStats(ctx, db)
+}
+
+// reportPoolStats logs db.Stats() on the same cadence as --interval until
+// ctx is cancelled, so pool contention is visible next to the workload's
+// own TPS/latency report. ctx is cancelled by the next applyPoolConfig
+// call (e.g. a failover reconnect), not just globalCtx, so at most one of
+// these runs per live db.
+func reportPoolStats(ctx context.Context, db *sql.DB) {
+	ticker := time.NewTicker(outputInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			logPoolStats(db)
+		}
+	}
+}
+
+// logPoolStats prints a db.Stats() snapshot, meant to be called alongside
+// the workload's own interval report so pool contention (WaitCount,
+// WaitDuration, MaxIdleClosed) doesn't masquerade as a slow server.
+func logPoolStats(db *sql.DB) {
+	if db == nil {
+		return
+	}
+	s := db.Stats()
+	fmt.Printf("pool stats: inUse=%d idle=%d waitCount=%d waitDuration=%s maxIdleClosed=%d maxLifetimeClosed=%d\n",
+		s.InUse, s.Idle, s.WaitCount, s.WaitDuration, s.MaxIdleClosed, s.MaxLifetimeClosed)
+}
+
 func main() {
 	var rootCmd = &cobra.Command{
 		Use:   "go-tpc",
@@ -96,13 +242,13 @@ func main() {
 	rootCmd.PersistentFlags().StringVar(&pprofAddr, "pprof", "", "Address of pprof endpoint")
 	rootCmd.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "", "Address of metrics endpoint")
 	rootCmd.PersistentFlags().StringVarP(&dbName, "db", "D", "test", "Database name")
-	rootCmd.PersistentFlags().StringVarP(&host, "host", "H", "127.0.0.1", "Database host")
+	rootCmd.PersistentFlags().StringVarP(&host, "host", "H", "127.0.0.1", "Database host, or a \",\"/\"||\"-separated failover group (e.g. primary:4000,replica:4000)")
 	rootCmd.PersistentFlags().StringVarP(&user, "user", "U", "root", "Database user")
 	rootCmd.PersistentFlags().StringVarP(&password, "password", "p", "", "Database password")
 	rootCmd.PersistentFlags().IntVarP(&port, "port", "P", 4000, "Database port")
 	rootCmd.PersistentFlags().IntVarP(&threads, "threads", "T", 1, "Thread concurrency")
 	rootCmd.PersistentFlags().IntVarP(&acThreads, "acThreads", "t", 1, "OLAP client concurrency, only for CH-benCHmark")
-	rootCmd.PersistentFlags().StringVarP(&driver, "driver", "d", "", "Database driver: mysql")
+	rootCmd.PersistentFlags().StringVarP(&driver, "driver", "d", "", "Database driver: mysql, postgres, sqlite")
 	rootCmd.PersistentFlags().DurationVar(&totalTime, "time", 1<<63-1, "Total execution time")
 	rootCmd.PersistentFlags().IntVar(&totalCount, "count", 0, "Total execution count, 0 means infinite")
 	rootCmd.PersistentFlags().BoolVar(&dropData, "dropdata", false, "Cleanup data before prepare")
@@ -113,6 +259,41 @@ func main() {
 2: ReadCommitted, 3: WriteCommitted, 4: RepeatableRead,
 5: Snapshot, 6: Serializable, 7: Linerizable`)
 	rootCmd.PersistentFlags().StringVar(&connParams, "conn-params", "", "session variables")
+	rootCmd.PersistentFlags().IntVar(&failoverRetries, "failover-retries", 3, "Number of failover attempts against the next --host entry before giving up")
+	rootCmd.PersistentFlags().DurationVar(&failoverBackoff, "failover-backoff", time.Second, "Backoff between failover attempts, multiplied by attempt number")
+	rootCmd.PersistentFlags().IntVar(&maxOpenConns, "max-open-conns", 0, "Max open connections to the database, 0 means threads+acThreads")
+	rootCmd.PersistentFlags().IntVar(&maxIdleConns, "max-idle-conns", 0, "Max idle connections kept in the pool, 0 means threads+acThreads+1")
+	rootCmd.PersistentFlags().DurationVar(&connMaxLifetime, "conn-max-lifetime", 0, "Max amount of time a connection may be reused, 0 means forever")
+	rootCmd.PersistentFlags().DurationVar(&connMaxIdleTime, "conn-max-idle-time", 0, "Max amount of time a connection may be idle before being closed, 0 means forever")
+	rootCmd.PersistentFlags().StringVar(&defaultsFile, "defaults-file", "", "Read user/password/host/port/socket from a my.cnf-style [client] section; explicit flags still win")
+	rootCmd.PersistentFlags().StringVar(&socket, "socket", "", "Unix socket path, takes precedence over --host/--port for mysql")
+	rootCmd.PersistentFlags().DurationVar(&stmtTimeout, "stmt-timeout", 0, "Cancel a single statement after this long, 0 means no deadline")
+	rootCmd.PersistentFlags().DurationVar(&txnTimeout, "txn-timeout", 0, "Cancel a whole transaction after this long, 0 means no deadline")
+	rootCmd.PersistentFlags().StringVar(&cpuprofile, "cpuprofile", "", "Write a CPU profile of this run to file")
+	rootCmd.PersistentFlags().StringVar(&memprofile, "memprofile", "", "Write a heap profile of this run to file on exit")
+	rootCmd.PersistentFlags().StringVar(&blockprofile, "blockprofile", "", "Write a goroutine blocking profile of this run to file on exit")
+	rootCmd.PersistentFlags().StringVar(&mutexprofile, "mutexprofile", "", "Write a mutex contention profile of this run to file on exit")
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		if defaultsFile != "" {
+			explicit := map[string]bool{
+				"user":     cmd.Flags().Changed("user"),
+				"password": cmd.Flags().Changed("password"),
+				"host":     cmd.Flags().Changed("host"),
+				"port":     cmd.Flags().Changed("port"),
+				"socket":   cmd.Flags().Changed("socket"),
+			}
+			if err := applyDefaultsFile(defaultsFile, explicit); err != nil {
+				return err
+			}
+		}
+		startFileProfiles()
+		// metricsAddr is only populated once cobra parses flags, which
+		// happens inside Execute() — starting the server any earlier would
+		// always see it empty and never actually listen.
+		startMetricsServer(metricsAddr)
+		return nil
+	}
 
 	cobra.EnablePrefixMatching = true
 
@@ -137,6 +318,7 @@ func main() {
 		sig := <-sc
 		fmt.Printf("\nGot signal [%v] to exit.\n", sig)
 		cancel()
+		stopFileProfiles()
 
 		select {
 		case <-sc:
@@ -154,4 +336,5 @@ func main() {
 	rootCmd.Execute()
 
 	cancel()
+	stopFileProfiles()
 }
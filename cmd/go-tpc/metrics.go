@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// startMetricsServer serves the Prometheus text exposition format at
+// --metrics-addr. It exports only the failover and statement-timeout
+// counters, from a ServeMux of its own; handlers registered elsewhere
+// (e.g. on http.DefaultServeMux) are not served here.
+func startMetricsServer(addr string) {
+	if addr == "" {
+		return
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "# HELP go_tpc_failover_events_total Number of times the client reconnected to a different --host entry after a transient error.\n")
+		fmt.Fprintf(w, "# TYPE go_tpc_failover_events_total counter\n")
+		fmt.Fprintf(w, "go_tpc_failover_events_total %d\n", atomic.LoadUint64(&failoverEvents))
+		fmt.Fprintf(w, "# HELP go_tpc_timeout_events_total Number of statements cancelled by --stmt-timeout/--txn-timeout.\n")
+		fmt.Fprintf(w, "# TYPE go_tpc_timeout_events_total counter\n")
+		fmt.Fprintf(w, "go_tpc_timeout_events_total %d\n", atomic.LoadUint64(&timeoutEvents))
+	})
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Printf("metrics server stopped: %v\n", err)
+		}
+	}()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+)
+
+var (
+	cpuprofile   string
+	memprofile   string
+	blockprofile string
+	mutexprofile string
+
+	cpuProfileFile *os.File
+	stopProfilesMu sync.Mutex
+)
+
+// startFileProfiles starts the profiles requested via --cpuprofile,
+// --memprofile, --blockprofile and --mutexprofile. Unlike --pprof, these
+// write directly to a file, which is the only option in a short batch
+// run or a CI container where nothing can reach an HTTP port. Call
+// stopFileProfiles on shutdown to flush them.
+func startFileProfiles() {
+	if cpuprofile != "" {
+		f, err := os.Create(cpuprofile)
+		if err != nil {
+			fmt.Printf("could not create cpu profile: %v\n", err)
+		} else if err := pprof.StartCPUProfile(f); err != nil {
+			fmt.Printf("could not start cpu profile: %v\n", err)
+			f.Close()
+		} else {
+			cpuProfileFile = f
+		}
+	}
+	if blockprofile != "" {
+		runtime.SetBlockProfileRate(1)
+	}
+	if mutexprofile != "" {
+		runtime.SetMutexProfileFraction(1)
+	}
+}
+
+// stopFileProfiles flushes every profile started by startFileProfiles. It
+// is safe to call even if none were requested, and safe to call
+// concurrently more than once (it's reached from both the signal handler
+// and the normal shutdown path, which can race on process exit).
+func stopFileProfiles() {
+	stopProfilesMu.Lock()
+	defer stopProfilesMu.Unlock()
+
+	if cpuProfileFile != nil {
+		pprof.StopCPUProfile()
+		cpuProfileFile.Close()
+		cpuProfileFile = nil
+	}
+	writeProfile(&memprofile, "heap")
+	writeProfile(&blockprofile, "block")
+	writeProfile(&mutexprofile, "mutex")
+}
+
+func writeProfile(path *string, name string) {
+	if *path == "" {
+		return
+	}
+	f, err := os.Create(*path)
+	*path = ""
+	if err != nil {
+		fmt.Printf("could not create %s profile: %v\n", name, err)
+		return
+	}
+	defer f.Close()
+	if name == "heap" {
+		runtime.GC()
+	}
+	if err := pprof.Lookup(name).WriteTo(f, 0); err != nil {
+		fmt.Printf("could not write %s profile: %v\n", name, err)
+	}
+}
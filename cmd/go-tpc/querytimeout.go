@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+)
+
+// timeoutEvents counts statements cancelled by --stmt-timeout/--txn-timeout,
+// kept apart from workloads' generic error counters so a run tuning
+// against --stmt-timeout doesn't look like it's failing outright.
+var timeoutEvents uint64
+
+// IsTimeout reports whether err is a cancellation produced by one of the
+// contexts ExecContext/QueryContext/BeginTxContext derive from
+// --stmt-timeout/--txn-timeout, as opposed to a real driver error.
+func IsTimeout(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// withStmtTimeout derives a context bounded by --stmt-timeout from parent,
+// returning a no-op cancel when the flag is unset (0) so callers can defer
+// the returned func unconditionally.
+func withStmtTimeout(parent context.Context) (context.Context, context.CancelFunc) {
+	if stmtTimeout <= 0 {
+		return parent, func() {}
+	}
+	return context.WithTimeout(parent, stmtTimeout)
+}
+
+// withTxnTimeout derives a context bounded by --txn-timeout, covering an
+// entire BeginTx...Commit/Rollback sequence rather than a single
+// statement.
+func withTxnTimeout(parent context.Context) (context.Context, context.CancelFunc) {
+	if txnTimeout <= 0 {
+		return parent, func() {}
+	}
+	return context.WithTimeout(parent, txnTimeout)
+}
+
+// ExecContext runs db.ExecContext under --stmt-timeout, bumping
+// timeoutEvents instead of the workload's own error counter when the
+// statement is cancelled by it.
+func ExecContext(ctx context.Context, db *sql.DB, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withStmtTimeout(ctx)
+	defer cancel()
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil && IsTimeout(err) {
+		atomic.AddUint64(&timeoutEvents, 1)
+	}
+	return res, err
+}
+
+// QueryContext runs db.QueryContext under --stmt-timeout, mirroring
+// ExecContext's timeout accounting. Unlike ExecContext, the returned Rows
+// outlives this call, so — like BeginTxContext — it hands cancel back to
+// the caller instead of deferring it: database/sql ties a Rows' lifetime
+// to the context it was opened with, and cancelling here would tear Rows
+// down before the caller gets to iterate it. Callers must call cancel
+// once they're done with Rows, typically via defer.
+func QueryContext(ctx context.Context, db *sql.DB, query string, args ...interface{}) (*sql.Rows, context.CancelFunc, error) {
+	ctx, cancel := withStmtTimeout(ctx)
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		if IsTimeout(err) {
+			atomic.AddUint64(&timeoutEvents, 1)
+		}
+		return nil, func() {}, err
+	}
+	return rows, cancel, nil
+}
+
+// PingContext runs db.PingContext under --stmt-timeout, mirroring
+// ExecContext's timeout accounting.
+func PingContext(ctx context.Context, db *sql.DB) error {
+	ctx, cancel := withStmtTimeout(ctx)
+	defer cancel()
+	err := db.PingContext(ctx)
+	if err != nil && IsTimeout(err) {
+		atomic.AddUint64(&timeoutEvents, 1)
+	}
+	return err
+}
+
+// BeginTxContext runs db.BeginTx under --txn-timeout. The returned
+// context must be used for every statement inside the transaction so the
+// deadline covers the whole txn, not just BeginTx itself.
+func BeginTxContext(ctx context.Context, db *sql.DB, opts *sql.TxOptions) (*sql.Tx, context.Context, context.CancelFunc, error) {
+	ctx, cancel := withTxnTimeout(ctx)
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		cancel()
+		if IsTimeout(err) {
+			atomic.AddUint64(&timeoutEvents, 1)
+		}
+		return nil, ctx, func() {}, err
+	}
+	return tx, ctx, cancel, nil
+}
+
+// ExecContextFailover runs query against globalDB like ExecContext, but
+// through withFailover: on a transient error it reconnects to the next
+// --host entry and retries, so a mid-benchmark failover actually recovers
+// the statement instead of ending the run. Workload code issuing
+// statements against the live benchmark connection should call this
+// instead of ExecContext, which runs against whatever *sql.DB it's given
+// (e.g. openDB's throwaway create-database connection) and has nothing to
+// fail over to.
+func ExecContextFailover(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	err := withFailover(func(db *sql.DB) error {
+		var err error
+		res, err = ExecContext(ctx, db, query, args...)
+		return err
+	})
+	return res, err
+}
+
+// QueryContextFailover is QueryContext's withFailover-retrying
+// counterpart; see ExecContextFailover. It carries the same cancel
+// contract as QueryContext: the caller must call the returned cancel
+// once done with Rows.
+func QueryContextFailover(ctx context.Context, query string, args ...interface{}) (*sql.Rows, context.CancelFunc, error) {
+	var (
+		rows   *sql.Rows
+		cancel context.CancelFunc
+	)
+	err := withFailover(func(db *sql.DB) error {
+		var err error
+		rows, cancel, err = QueryContext(ctx, db, query, args...)
+		return err
+	})
+	return rows, cancel, err
+}
+
+// BeginTxContextFailover is BeginTxContext's withFailover-retrying
+// counterpart: a transient error opening the transaction reconnects to
+// the next --host entry and retries BeginTx. It does not retry
+// statements issued after Begin succeeds, so a transient error mid-
+// transaction still fails the caller's txn, same as plain BeginTxContext.
+func BeginTxContextFailover(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, context.Context, context.CancelFunc, error) {
+	var (
+		tx     *sql.Tx
+		txCtx  context.Context
+		cancel context.CancelFunc
+	)
+	err := withFailover(func(db *sql.DB) error {
+		var err error
+		tx, txCtx, cancel, err = BeginTxContext(ctx, db, opts)
+		return err
+	})
+	return tx, txCtx, cancel, err
+}